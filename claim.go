@@ -0,0 +1,38 @@
+package magic
+
+import "encoding/json"
+
+// Claim is the strongly-typed form of a DID token's claim. It covers every
+// field Magic issues today, plus Add for the attachment field Magic's
+// extension tokens carry. Anything else present in the claim — including
+// these same fields, undecoded — is kept in Raw so callers can read
+// forward-compatible or application-specific claims without having to
+// re-parse the token.
+type Claim struct {
+	Iat int64  `json:"iat"`
+	Ext int64  `json:"ext"`
+	Nbf int64  `json:"nbf"`
+	Iss string `json:"iss"`
+	Sub string `json:"sub"`
+	Aud string `json:"aud"`
+	Tid string `json:"tid"`
+	Add string `json:"add,omitempty"`
+
+	// Raw holds every field of the claim, undecoded, keyed by name.
+	Raw map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the known fields into their typed counterparts and
+// keeps the full set of fields, undecoded, in Raw.
+func (c *Claim) UnmarshalJSON(data []byte) error {
+	type knownFields Claim
+	var kf knownFields
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &kf.Raw); err != nil {
+		return err
+	}
+	*c = Claim(kf)
+	return nil
+}
@@ -0,0 +1,47 @@
+package magic
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestClaimUnmarshalJSONRawPassthrough(t *testing.T) {
+	data := []byte(`{
+		"iat": 1000,
+		"ext": 2000,
+		"nbf": 900,
+		"iss": "did:ethr:0x1",
+		"sub": "did:ethr:0x1",
+		"aud": "client-id",
+		"tid": "tid-1",
+		"custom_field": "custom-value",
+		"nested": {"a": 1}
+	}`)
+
+	var claim Claim
+	if err := json.Unmarshal(data, &claim); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if claim.Iat != 1000 || claim.Ext != 2000 || claim.Nbf != 900 {
+		t.Fatalf("typed numeric fields = %+v, unexpected", claim)
+	}
+	if claim.Iss != "did:ethr:0x1" || claim.Sub != "did:ethr:0x1" || claim.Aud != "client-id" || claim.Tid != "tid-1" {
+		t.Fatalf("typed string fields = %+v, unexpected", claim)
+	}
+
+	var custom string
+	if err := json.Unmarshal(claim.Raw["custom_field"], &custom); err != nil {
+		t.Fatalf("unmarshal Raw[\"custom_field\"]: %v", err)
+	}
+	if custom != "custom-value" {
+		t.Fatalf("Raw[\"custom_field\"] = %q, want custom-value", custom)
+	}
+
+	if _, ok := claim.Raw["iss"]; !ok {
+		t.Fatalf("Raw is missing known field %q", "iss")
+	}
+	if _, ok := claim.Raw["nested"]; !ok {
+		t.Fatalf("Raw is missing unknown field %q", "nested")
+	}
+}
@@ -0,0 +1,206 @@
+package magic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultAPIBaseURL is the Admin API base URL used when a Client is created
+// without WithBaseURL.
+const DefaultAPIBaseURL = "https://api.magic.link"
+
+// DefaultTimeout is the per-request timeout used when a Client is created
+// without WithTimeout.
+const DefaultTimeout = 10 * time.Second
+
+// UserMetadata is a Magic user's metadata, as returned by the Admin API's
+// get_metadata_by_issuer and get_metadata_by_public_address endpoints.
+type UserMetadata struct {
+	Issuer        string `json:"issuer"`
+	Email         string `json:"email"`
+	PublicAddress string `json:"public_address"`
+	OAuthProvider string `json:"oauth_provider"`
+}
+
+// APIError is returned when the Admin API responds with a non-2xx status or
+// a failed envelope. Callers can branch on it with errors.As.
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("magic: admin API error %d (%s): %s", e.Status, e.Code, e.Message)
+}
+
+// apiEnvelope is the response shape every Magic Admin API endpoint wraps its
+// payload in.
+type apiEnvelope struct {
+	Status    string          `json:"status"`
+	ErrorCode string          `json:"error_code"`
+	Message   string          `json:"message"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Client wraps Magic's Admin REST API (https://magic.link/docs/api) for
+// server-side token introspection and user lookups.
+type Client struct {
+	secretKey  string
+	baseURL    string
+	httpClient *http.Client
+	timeout    time.Duration
+	maxRetries int
+}
+
+// ClientOption configures a Client returned by NewClient.
+type ClientOption func(*Client)
+
+// WithHTTPClient overrides the http.Client used for Admin API requests. The
+// client is used as-is and is never mutated; use WithTimeout to bound how
+// long a request may take instead of setting httpClient.Timeout yourself,
+// so a client shared with other callers isn't affected.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithBaseURL overrides the Admin API base URL, e.g. to point at a mock
+// server in tests.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) { c.baseURL = baseURL }
+}
+
+// WithTimeout bounds how long a single Admin API request may take. It's
+// applied per-request via context.WithTimeout rather than by mutating the
+// configured http.Client, so it's safe to combine with a WithHTTPClient
+// shared elsewhere in the caller's app.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) { c.timeout = timeout }
+}
+
+// WithMaxRetries sets how many additional attempts are made after a
+// transport error or a 5xx response. It defaults to 0 (no retries).
+func WithMaxRetries(maxRetries int) ClientOption {
+	return func(c *Client) { c.maxRetries = maxRetries }
+}
+
+// NewClient returns a Client that authenticates Admin API requests with
+// secretKey, the secret key issued from the Magic dashboard.
+func NewClient(secretKey string, opts ...ClientOption) *Client {
+	c := &Client{
+		secretKey:  secretKey,
+		baseURL:    DefaultAPIBaseURL,
+		httpClient: http.DefaultClient,
+		timeout:    DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ValidateToken runs the same checks as (*Token).Validate and additionally
+// confirms the token's issuer is known to Magic via the Admin API.
+func (c *Client) ValidateToken(ctx context.Context, didToken string) error {
+	return New(didToken).ValidateWithClient(ctx, c)
+}
+
+// GetMetadataByIssuer fetches the metadata of the user identified by iss
+// (a DID token's "iss" claim).
+func (c *Client) GetMetadataByIssuer(ctx context.Context, iss string) (*UserMetadata, error) {
+	var metadata UserMetadata
+	query := url.Values{"issuer": []string{iss}}
+	if err := c.do(ctx, http.MethodGet, "/v1/admin/auth/user/get_metadata_by_issuer", query, &metadata); err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
+
+// GetMetadataByPublicAddress fetches the metadata of the user identified by
+// publicAddress.
+func (c *Client) GetMetadataByPublicAddress(ctx context.Context, publicAddress string) (*UserMetadata, error) {
+	var metadata UserMetadata
+	query := url.Values{"public_address": []string{publicAddress}}
+	if err := c.do(ctx, http.MethodGet, "/v1/admin/auth/user/get_metadata_by_public_address", query, &metadata); err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
+
+// LogoutByIssuer logs the user identified by iss out of all of their active
+// Magic sessions.
+func (c *Client) LogoutByIssuer(ctx context.Context, iss string) error {
+	query := url.Values{"issuer": []string{iss}}
+	return c.do(ctx, http.MethodPost, "/v1/admin/auth/user/logout", query, nil)
+}
+
+// do issues a single Admin API request, retrying transport errors and 5xx
+// responses up to c.maxRetries times, and decodes the envelope's data field
+// into out.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, out interface{}) error {
+	requestURL := c.baseURL + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		err, retry := c.attempt(ctx, method, requestURL, out)
+		if !retry {
+			return err
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+// attempt performs a single HTTP round trip. retry reports whether do
+// should try again (a transport error or a 5xx response); err is always
+// the error or nil to return from do when retry is false.
+func (c *Client) attempt(ctx context.Context, method, requestURL string, out interface{}) (err error, retry bool) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, nil)
+	if err != nil {
+		return err, false
+	}
+	req.Header.Set("X-Magic-Secret-Key", c.secretKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err, true
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err, true
+	}
+
+	var envelope apiEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("magic: malformed Admin API response: %w", err), resp.StatusCode >= 500
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 && envelope.Status != "failed" {
+		if out != nil && len(envelope.Data) > 0 {
+			return json.Unmarshal(envelope.Data, out), false
+		}
+		return nil, false
+	}
+
+	apiErr := &APIError{Status: resp.StatusCode, Code: envelope.ErrorCode, Message: envelope.Message}
+	if resp.StatusCode >= 500 {
+		return apiErr, true
+	}
+	return apiErr, false
+}
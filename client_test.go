@@ -0,0 +1,100 @@
+package magic
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClientGetMetadataByIssuerHappyPath(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Magic-Secret-Key"); got != "sk_test_123" {
+			t.Errorf("secret key header = %q, want sk_test_123", got)
+		}
+		if got := r.URL.Query().Get("issuer"); got != "did:ethr:0x1" {
+			t.Errorf("issuer query param = %q, want did:ethr:0x1", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"status": "ok",
+			"error_code": "",
+			"message": "",
+			"data": {
+				"issuer": "did:ethr:0x1",
+				"email": "user@example.com",
+				"public_address": "0x1",
+				"oauth_provider": "google"
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	c := NewClient("sk_test_123", WithBaseURL(server.URL))
+
+	metadata, err := c.GetMetadataByIssuer(context.Background(), "did:ethr:0x1")
+	if err != nil {
+		t.Fatalf("GetMetadataByIssuer() error = %v", err)
+	}
+	if metadata.Email != "user@example.com" || metadata.PublicAddress != "0x1" || metadata.OAuthProvider != "google" {
+		t.Fatalf("GetMetadataByIssuer() = %+v, unexpected fields", metadata)
+	}
+}
+
+func TestClientRetriesOn5xx(t *testing.T) {
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requestCount, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"status":"failed","error_code":"server_error","message":"try again"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"ok","data":{"issuer":"did:ethr:0x1"}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient("sk_test_123", WithBaseURL(server.URL), WithMaxRetries(2))
+
+	metadata, err := c.GetMetadataByIssuer(context.Background(), "did:ethr:0x1")
+	if err != nil {
+		t.Fatalf("GetMetadataByIssuer() error = %v", err)
+	}
+	if metadata.Issuer != "did:ethr:0x1" {
+		t.Fatalf("GetMetadataByIssuer() = %+v, want issuer did:ethr:0x1", metadata)
+	}
+	if got := atomic.LoadInt32(&requestCount); got != 3 {
+		t.Fatalf("server saw %d requests, want 3 (2 failures + 1 success)", got)
+	}
+}
+
+func TestClientReturnsAPIErrorOn4xx(t *testing.T) {
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requestCount, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"status":"failed","error_code":"invalid_issuer","message":"issuer is malformed"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient("sk_test_123", WithBaseURL(server.URL), WithMaxRetries(2))
+
+	_, err := c.GetMetadataByIssuer(context.Background(), "not-a-real-issuer")
+	if err == nil {
+		t.Fatalf("GetMetadataByIssuer() = nil error, want *APIError")
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("GetMetadataByIssuer() error = %v, want *APIError", err)
+	}
+	if apiErr.Status != http.StatusBadRequest || apiErr.Code != "invalid_issuer" {
+		t.Fatalf("APIError = %+v, unexpected fields", apiErr)
+	}
+
+	if got := atomic.LoadInt32(&requestCount); got != 1 {
+		t.Fatalf("server saw %d requests, want 1 (4xx responses aren't retried)", got)
+	}
+}
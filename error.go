@@ -1,8 +1,35 @@
 package magic
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
-//DIDTokenError token error
+// Sentinel errors returned by this package, wrapped in a *DIDTokenError so
+// callers can branch on the failure reason with errors.Is/errors.As instead
+// of matching error message strings.
+var (
+	// ErrSignatureMismatch is returned when the recovered address from the
+	// 'proof' does not match the issuer's public address encoded in 'claim',
+	// and also when the 'proof' is not a hex-encoded 65-byte signature or no
+	// public key can be recovered from it.
+	ErrSignatureMismatch = errors.New("signature mismatch between 'proof' and 'claim'")
+	ErrMalformedToken    = errors.New("DID token is malformed")
+	ErrMalformedIssuer   = errors.New("DID token issuer is malformed")
+	ErrMissingClaim      = errors.New("DID token is missing a required claim")
+	ErrTokenExpired      = errors.New("DID token has expired")
+	ErrTokenNotYetValid  = errors.New("DID token is not yet valid")
+	ErrIssuerMismatch    = errors.New("DID token issuer does not match the expected issuer")
+	ErrAudienceMismatch  = errors.New("DID token audience does not match the expected audience")
+	ErrSubjectMismatch   = errors.New("DID token subject does not match the expected subject")
+	ErrTokenReplayed     = errors.New("DID token has already been used")
+)
+
+// ErrMissingRequiredField is an alias for ErrMissingClaim, kept so callers
+// can match on whichever name reads better at the call site.
+var ErrMissingRequiredField = ErrMissingClaim
+
+// DIDTokenError token error
 type DIDTokenError struct {
 	Message string
 	Err     error
@@ -11,3 +38,9 @@ type DIDTokenError struct {
 func (err *DIDTokenError) Error() string {
 	return fmt.Sprintf("%s\n %v", err.Message, err.Err)
 }
+
+// Unwrap returns the sentinel error wrapped by err, so that
+// errors.Is(err, magic.ErrTokenExpired) (and errors.As) work as expected.
+func (err *DIDTokenError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,74 @@
+package magic
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ReplayStore lets callers reject DID tokens whose "tid" claim has already
+// been used. SeenOrRecord must atomically check whether tid has been seen
+// before and, if not, record it for ttl. It returns true if tid was already
+// recorded (i.e. the token is a replay).
+//
+// Implementations are expected to be safe for concurrent use. A Redis or
+// Memcached-backed store typically implements this with a single
+// SET key value NX EX ttl (or equivalent) call: the command both performs
+// the check and records the tid atomically.
+type ReplayStore interface {
+	SeenOrRecord(ctx context.Context, tid string, ttl time.Duration) (bool, error)
+}
+
+// NoopReplayStore is a ReplayStore that never rejects anything. It exists so
+// replay protection can be explicitly opted out of (e.g. when it's handled
+// further up the stack) rather than relying on a nil Validator.ReplayStore.
+type NoopReplayStore struct{}
+
+// SeenOrRecord always reports tid as unseen.
+func (NoopReplayStore) SeenOrRecord(ctx context.Context, tid string, ttl time.Duration) (bool, error) {
+	return false, nil
+}
+
+// MemoryReplayStore is an in-memory, process-local ReplayStore backed by a
+// TTL map. Expired entries are evicted lazily on access, so it needs no
+// background goroutine. It is only suitable for a single process; deployments
+// with more than one server instance should implement ReplayStore against a
+// shared store such as Redis or Memcached.
+type MemoryReplayStore struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+// NewMemoryReplayStore returns a ready-to-use MemoryReplayStore.
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{expires: make(map[string]time.Time)}
+}
+
+// SeenOrRecord reports whether tid was already recorded and, if not, records
+// it until ttl elapses. A ttl of zero or less is treated as one second.
+func (s *MemoryReplayStore) SeenOrRecord(ctx context.Context, tid string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evictExpiredLocked(now)
+
+	if expiresAt, ok := s.expires[tid]; ok && now.Before(expiresAt) {
+		return true, nil
+	}
+
+	if ttl <= 0 {
+		ttl = time.Second
+	}
+	s.expires[tid] = now.Add(ttl)
+	return false, nil
+}
+
+func (s *MemoryReplayStore) evictExpiredLocked(now time.Time) {
+	for tid, expiresAt := range s.expires {
+		if now.After(expiresAt) {
+			delete(s.expires, tid)
+		}
+	}
+}
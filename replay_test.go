@@ -0,0 +1,83 @@
+package magic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryReplayStoreSeenOrRecord(t *testing.T) {
+	store := NewMemoryReplayStore()
+	ctx := context.Background()
+
+	seen, err := store.SeenOrRecord(ctx, "tid-1", time.Minute)
+	if err != nil {
+		t.Fatalf("SeenOrRecord() error = %v", err)
+	}
+	if seen {
+		t.Fatalf("SeenOrRecord() = true on first call, want false")
+	}
+
+	seen, err = store.SeenOrRecord(ctx, "tid-1", time.Minute)
+	if err != nil {
+		t.Fatalf("SeenOrRecord() error = %v", err)
+	}
+	if !seen {
+		t.Fatalf("SeenOrRecord() = false on replay, want true")
+	}
+}
+
+func TestMemoryReplayStoreExpiry(t *testing.T) {
+	store := NewMemoryReplayStore()
+	ctx := context.Background()
+
+	if seen, err := store.SeenOrRecord(ctx, "tid-1", time.Millisecond); err != nil || seen {
+		t.Fatalf("SeenOrRecord() = (%v, %v), want (false, nil)", seen, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	if seen, err := store.SeenOrRecord(ctx, "tid-1", time.Minute); err != nil || seen {
+		t.Fatalf("SeenOrRecord() after expiry = (%v, %v), want (false, nil)", seen, err)
+	}
+}
+
+func TestTokenValidateWithRejectsReplay(t *testing.T) {
+	didToken := newSignedDIDToken(t, nil)
+	v := &Validator{ReplayStore: NewMemoryReplayStore()}
+	ctx := context.Background()
+
+	if err := New(didToken).ValidateWith(ctx, v); err != nil {
+		t.Fatalf("first ValidateWith() = %v, want nil", err)
+	}
+
+	err := New(didToken).ValidateWith(ctx, v)
+	if err == nil {
+		t.Fatalf("second ValidateWith() = nil, want error wrapping ErrTokenReplayed")
+	}
+	if !errors.Is(err, ErrTokenReplayed) {
+		t.Fatalf("second ValidateWith() = %v, want error wrapping ErrTokenReplayed", err)
+	}
+}
+
+// TestTokenValidateWithDoesNotRecordReplayOnUnrelatedMismatch ensures a
+// rejection on an unrelated claim mismatch doesn't burn the token's tid: a
+// later, correctly-configured call on the very same token must still
+// succeed instead of failing as a replay.
+func TestTokenValidateWithDoesNotRecordReplayOnUnrelatedMismatch(t *testing.T) {
+	didToken := newSignedDIDToken(t, nil)
+	store := NewMemoryReplayStore()
+	ctx := context.Background()
+
+	misconfigured := &Validator{ReplayStore: store, ExpectedAudience: "wrong-audience"}
+	err := New(didToken).ValidateWith(ctx, misconfigured)
+	if !errors.Is(err, ErrAudienceMismatch) {
+		t.Fatalf("ValidateWith() with wrong audience = %v, want error wrapping ErrAudienceMismatch", err)
+	}
+
+	correct := &Validator{ReplayStore: store}
+	if err := New(didToken).ValidateWith(ctx, correct); err != nil {
+		t.Fatalf("ValidateWith() after unrelated mismatch = %v, want nil", err)
+	}
+}
@@ -1,12 +1,16 @@
 package magic
 
 import (
+	"context"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // ExpectedDIDTokenContentLength content
@@ -15,8 +19,10 @@ const ExpectedDIDTokenContentLength = 2
 // DIDTokenNBFGracePeriod A grace period time in second applied to the nbf field for token validation.
 const DIDTokenNBFGracePeriod = 300
 
-// RequiredFields required fields
-var RequiredFields = [7]string{
+// defaultRequiredFields are the claims a DID token is always expected to
+// carry. It is deliberately unexported so callers can't mutate the
+// baseline set at runtime; use Validator.AdditionalRequiredFields to extend it.
+var defaultRequiredFields = []string{
 	"iat",
 	"ext",
 	"nbf",
@@ -26,42 +32,47 @@ var RequiredFields = [7]string{
 	"tid",
 }
 
-// Token struct holding the didToken string, the proof and the claim (once decoded)
+// Token struct holding the didToken string and, once decoded, its proof and
+// claim. decode() populates and reuses these fields so that Decode, Claim,
+// Issuer, PublicAddress, Validate and ValidateWith all share a single parse
+// of a given token instead of re-decoding it on every call.
 type Token struct {
-	didToken string
-	proof    string
-	claim    map[string]interface{}
+	didToken   string
+	proof      string
+	claim      map[string]interface{}
+	rawClaim   string
+	typedClaim *Claim
 }
 
-//New contructs a new token
+// New contructs a new token
 func New(didToken string) *Token {
 	return &Token{didToken: didToken}
 }
 
-func checkRequiredFields(claim map[string]interface{}) error {
+func checkRequiredFields(claim map[string]interface{}, fields []string) error {
 	var missingFields []string
-	for _, field := range RequiredFields {
+	for _, field := range fields {
 		if _, ok := claim[field]; !ok {
 			missingFields = append(missingFields, field)
 		}
 	}
 
 	if len(missingFields) > 0 {
-		return fmt.Errorf("DID token is missing required field(s): {%s}", missingFields)
+		return &DIDTokenError{
+			Message: fmt.Sprintf("DID token is missing required field(s): {%s}", missingFields),
+			Err:     ErrMissingClaim,
+		}
 	}
 	return nil
 }
 
 // Issuer Extracts the iss from the DID Token.
 func (t *Token) Issuer() (string, error) {
-	if t.claim == nil {
-		var err error
-		_, t.claim, err = t.Decode()
-		if err != nil {
-			return "", err
-		}
+	claim, err := t.Claim()
+	if err != nil {
+		return "", err
 	}
-	return t.claim["iss"].(string), nil
+	return claim.Iss, nil
 }
 
 // PublicAddress public address of the issuer
@@ -72,70 +83,267 @@ func (t *Token) PublicAddress() (string, error) {
 	}
 	siss := strings.Split(iss, ":")
 	if siss == nil || len(siss) < 3 {
-		return "", fmt.Errorf("Given issuer (%s) is malformed. Please make sure it follows the `did:method-name:method-specific-id` format", iss)
+		return "", &DIDTokenError{
+			Message: fmt.Sprintf("Given issuer (%s) is malformed. Please make sure it follows the `did:method-name:method-specific-id` format", iss),
+			Err:     ErrMalformedIssuer,
+		}
 	}
 	return strings.Split(iss, ":")[2], nil
 }
 
-//Decode decode the didToken
-func (t *Token) Decode() (string, map[string]interface{}, error) {
+// decode parses t.didToken into its proof, map-form claim, raw claim string
+// and typed claim, caching the result on t so that repeated calls (from
+// Decode, Claim, Issuer, Validate, ValidateWith, ...) only parse the token
+// once.
+func (t *Token) decode() (string, map[string]interface{}, string, *Claim, error) {
+	if t.claim != nil {
+		return t.proof, t.claim, t.rawClaim, t.typedClaim, nil
+	}
+
 	decodedDIDToken, err := base64.StdEncoding.DecodeString(t.didToken)
 	if err != nil {
-		return "", nil, errors.New("DID token is malformed. It has to be a based64 encoded JSON serialized string")
+		return "", nil, "", nil, &DIDTokenError{
+			Message: "DID token is malformed. It has to be a based64 encoded JSON serialized string",
+			Err:     ErrMalformedToken,
+		}
 	}
 
 	var jsonDIDToken []string
 	if err = json.Unmarshal(decodedDIDToken, &jsonDIDToken); err != nil {
-		return "", nil, errors.New("DID token is malformed. It has to be a based64 encoded JSON serialized string")
+		return "", nil, "", nil, &DIDTokenError{
+			Message: "DID token is malformed. It has to be a based64 encoded JSON serialized string",
+			Err:     ErrMalformedToken,
+		}
 	}
 
 	if len(jsonDIDToken) != ExpectedDIDTokenContentLength {
-		return "", nil, errors.New("DID token is malformed. It has to have two parts [proof, claim]")
+		return "", nil, "", nil, &DIDTokenError{
+			Message: "DID token is malformed. It has to have two parts [proof, claim]",
+			Err:     ErrMalformedToken,
+		}
 	}
 
 	proof := jsonDIDToken[0]
+	rawClaim := jsonDIDToken[1]
+
+	var typedClaim Claim
+	if err = json.Unmarshal([]byte(rawClaim), &typedClaim); err != nil {
+		return "", nil, "", nil, &DIDTokenError{
+			Message: "DID token is malformed. Given claim should be a JSON serialized string",
+			Err:     ErrMalformedToken,
+		}
+	}
 
 	var claim map[string]interface{}
-	if err = json.Unmarshal([]byte(jsonDIDToken[1]), &claim); err != nil {
-		return "", nil, errors.New("DID token is malformed. Given claim should be a JSON serialized string")
+	if err = json.Unmarshal([]byte(rawClaim), &claim); err != nil {
+		return "", nil, "", nil, &DIDTokenError{
+			Message: "DID token is malformed. Given claim should be a JSON serialized string",
+			Err:     ErrMalformedToken,
+		}
+	}
+
+	if err := checkRequiredFields(claim, defaultRequiredFields); err != nil {
+		return "", nil, "", nil, err
+	}
+
+	t.proof, t.claim, t.rawClaim, t.typedClaim = proof, claim, rawClaim, &typedClaim
+	return proof, claim, rawClaim, &typedClaim, nil
+}
+
+// Decode decode the didToken. In addition to the proof and the parsed claim,
+// it returns the raw (still JSON-serialized) claim string exactly as it was
+// signed, since re-marshaling a map does not preserve field order and would
+// invalidate signature verification.
+func (t *Token) Decode() (string, map[string]interface{}, string, error) {
+	proof, claim, rawClaim, _, err := t.decode()
+	return proof, claim, rawClaim, err
+}
+
+// Claim returns the strongly-typed claim for this token, decoding it if
+// necessary. Unlike reading straight from the map returned by Decode, this
+// never panics on a malformed or missing field: a type mismatch simply
+// surfaces as an error.
+func (t *Token) Claim() (*Claim, error) {
+	_, _, _, typedClaim, err := t.decode()
+	if err != nil {
+		return nil, err
+	}
+	return typedClaim, nil
+}
+
+// verifySignature recovers the address that produced proof over rawClaim
+// (hashed per EIP-191 personal_sign) and checks it against publicAddress.
+func verifySignature(proof, rawClaim, publicAddress string) error {
+	sig, err := hex.DecodeString(strings.TrimPrefix(proof, "0x"))
+	if err != nil || len(sig) != 65 {
+		return &DIDTokenError{
+			Message: "Given proof is malformed. It has to be a hex-encoded 65-byte secp256k1 signature (r||s||v)",
+			Err:     ErrSignatureMismatch,
+		}
 	}
-	err = checkRequiredFields(claim)
+
+	// crypto.Ecrecover expects the recovery id in the last byte to be 0 or 1,
+	// while Ethereum signatures commonly encode it as 27/28.
+	sig = append([]byte(nil), sig...)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+
+	msg := "\x19Ethereum Signed Message:\n" + strconv.Itoa(len(rawClaim)) + rawClaim
+	hash := crypto.Keccak256([]byte(msg))
+
+	pubKey, err := crypto.Ecrecover(hash, sig)
 	if err != nil {
-		return "", nil, err
+		return &DIDTokenError{
+			Message: "Unable to recover a public key from the given proof",
+			Err:     ErrSignatureMismatch,
+		}
 	}
 
-	return proof, claim, nil
+	// pubKey is the uncompressed point with a leading 0x04 prefix byte;
+	// the address is the last 20 bytes of the Keccak-256 hash of the
+	// remaining 64 bytes.
+	addressHash := crypto.Keccak256(pubKey[1:])
+	recoveredAddress := "0x" + hex.EncodeToString(addressHash[len(addressHash)-20:])
+
+	if !strings.EqualFold(recoveredAddress, publicAddress) {
+		return &DIDTokenError{
+			Message: "Signature mismatch between 'proof' and 'claim'. Please generate a new token with an intended issuer.",
+			Err:     ErrSignatureMismatch,
+		}
+	}
+	return nil
 }
 
 // Validate validate
 func (t *Token) Validate() error {
-	_, claim, err := t.Decode()
-	_, err = json.Marshal(claim)
+	proof, _, rawClaim, claim, err := t.decode()
 	if err != nil {
 		return err
 	}
 
-	/*
-		signature := proof[:len(proof)-1] // remove recovery id
-		var recoveredAddress []byte
-		_ = crypto.VerifySignature(recoveredAddress, msg, []byte(signature)) // Ignoring this until I figure out how to do it
+	publicAddress, err := t.PublicAddress()
+	if err != nil {
+		return err
+	}
 
-		if false && (string(recoveredAddress) != t.PublicAddress()) {
-			panic(&DIDTokenError{
-				Message: "Signature mismatch between 'proof' and 'claim'. Please generate a new token with an intended issuer.",
-				Err:     nil,
-			})
-		}
-	*/
+	if err := verifySignature(proof, rawClaim, publicAddress); err != nil {
+		return err
+	}
 
 	currentTime := time.Now().Unix()
 
-	if currentTime > int64(claim["ext"].(float64)) {
-		return fmt.Errorf("Given DID token has expired. Please generate a new one")
+	if currentTime > claim.Ext {
+		return &DIDTokenError{
+			Message: "Given DID token has expired. Please generate a new one",
+			Err:     ErrTokenExpired,
+		}
 	}
 
-	if currentTime < (int64(claim["nbf"].(float64)) - DIDTokenNBFGracePeriod) {
-		return fmt.Errorf("Given DID token cannot be used at this time. Please check the 'nbf' field and regenerate a new token with a suitable value")
+	if currentTime < (claim.Nbf - DIDTokenNBFGracePeriod) {
+		return &DIDTokenError{
+			Message: "Given DID token cannot be used at this time. Please check the 'nbf' field and regenerate a new token with a suitable value",
+			Err:     ErrTokenNotYetValid,
+		}
 	}
 	return nil
 }
+
+// ValidateWith validates the token the same way Validate does, plus
+// whatever additional checks are configured on v: expected issuer,
+// audience and subject, a custom leeway/clock, any extra required fields,
+// and replay protection via v.ReplayStore. A nil *Validator behaves like a
+// zero-value one. ctx is only used to pass along to v.ReplayStore.
+func (t *Token) ValidateWith(ctx context.Context, v *Validator) error {
+	proof, rawClaimFields, rawClaim, claim, err := t.decode()
+	if err != nil {
+		return err
+	}
+	if err := checkRequiredFields(rawClaimFields, v.requiredFields()); err != nil {
+		return err
+	}
+
+	publicAddress, err := t.PublicAddress()
+	if err != nil {
+		return err
+	}
+	if err := verifySignature(proof, rawClaim, publicAddress); err != nil {
+		return err
+	}
+
+	now := v.now().Unix()
+	leeway := int64(v.leeway().Seconds())
+
+	if now > claim.Ext {
+		return &DIDTokenError{
+			Message: "Given DID token has expired. Please generate a new one",
+			Err:     ErrTokenExpired,
+		}
+	}
+	if now < claim.Nbf-leeway {
+		return &DIDTokenError{
+			Message: "Given DID token cannot be used at this time. Please check the 'nbf' field and regenerate a new token with a suitable value",
+			Err:     ErrTokenNotYetValid,
+		}
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	if v.ExpectedIssuer != "" && claim.Iss != v.ExpectedIssuer {
+		return &DIDTokenError{
+			Message: fmt.Sprintf("DID token issuer (%s) does not match expected issuer (%s)", claim.Iss, v.ExpectedIssuer),
+			Err:     ErrIssuerMismatch,
+		}
+	}
+	if v.ExpectedAudience != "" && claim.Aud != v.ExpectedAudience {
+		return &DIDTokenError{
+			Message: fmt.Sprintf("DID token audience (%s) does not match expected audience (%s)", claim.Aud, v.ExpectedAudience),
+			Err:     ErrAudienceMismatch,
+		}
+	}
+	if v.ExpectedSubject != "" && claim.Sub != v.ExpectedSubject {
+		return &DIDTokenError{
+			Message: fmt.Sprintf("DID token subject (%s) does not match expected subject (%s)", claim.Sub, v.ExpectedSubject),
+			Err:     ErrSubjectMismatch,
+		}
+	}
+
+	// Only record the tid as seen once every other check has passed: a
+	// rejection on an unrelated mismatch above must not burn the token's
+	// replay slot, or a later, correctly-configured call on the very same
+	// (never actually accepted) token would wrongly fail as a replay.
+	if v.ReplayStore != nil {
+		ttl := time.Duration(claim.Ext-now) * time.Second
+		seen, err := v.ReplayStore.SeenOrRecord(ctx, claim.Tid, ttl)
+		if err != nil {
+			return err
+		}
+		if seen {
+			return &DIDTokenError{
+				Message: "Given DID token has already been used and was rejected as a replay",
+				Err:     ErrTokenReplayed,
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateWithClient validates the token locally (the same checks Validate
+// performs) and additionally confirms its issuer is known to Magic by
+// looking up its metadata through c.
+func (t *Token) ValidateWithClient(ctx context.Context, c *Client) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	iss, err := t.Issuer()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.GetMetadataByIssuer(ctx, iss)
+	return err
+}
@@ -0,0 +1,135 @@
+package magic
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// newSignedDIDToken builds a didToken string signed by a freshly generated
+// secp256k1 key, in the same [proof, claim] shape (*Token).Decode expects.
+// mutateClaim, if non-nil, is applied to the claim map after the signature
+// is computed but before it's embedded in the token, letting tests simulate
+// tampering.
+func newSignedDIDToken(t *testing.T, mutateClaim func(claim map[string]interface{})) string {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	now := time.Now().Unix()
+	claim := map[string]interface{}{
+		"iat": now,
+		"ext": now + 300,
+		"nbf": now - 10,
+		"iss": "did:ethr:" + address,
+		"sub": "did:ethr:" + address,
+		"aud": "test-client-id",
+		"tid": "test-tid-1",
+	}
+
+	rawClaim, err := json.Marshal(claim)
+	if err != nil {
+		t.Fatalf("marshal claim: %v", err)
+	}
+
+	msg := "\x19Ethereum Signed Message:\n" + strconv.Itoa(len(rawClaim)) + string(rawClaim)
+	hash := crypto.Keccak256([]byte(msg))
+
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	proof := hex.EncodeToString(sig)
+
+	if mutateClaim != nil {
+		mutateClaim(claim)
+		rawClaim, err = json.Marshal(claim)
+		if err != nil {
+			t.Fatalf("marshal mutated claim: %v", err)
+		}
+	}
+
+	tokenJSON, err := json.Marshal([]string{proof, string(rawClaim)})
+	if err != nil {
+		t.Fatalf("marshal token: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(tokenJSON)
+}
+
+func TestTokenValidateSignature(t *testing.T) {
+	tests := []struct {
+		name      string
+		didToken  func(t *testing.T) string
+		wantErr   error
+		wantValid bool
+	}{
+		{
+			name: "valid signature",
+			didToken: func(t *testing.T) string {
+				return newSignedDIDToken(t, nil)
+			},
+			wantValid: true,
+		},
+		{
+			name: "tampered claim",
+			didToken: func(t *testing.T) string {
+				return newSignedDIDToken(t, func(claim map[string]interface{}) {
+					claim["sub"] = "did:ethr:0x000000000000000000000000000000000000ff"
+				})
+			},
+			wantErr: ErrSignatureMismatch,
+		},
+		{
+			name: "malformed proof",
+			didToken: func(t *testing.T) string {
+				didToken := newSignedDIDToken(t, nil)
+				decoded, err := base64.StdEncoding.DecodeString(didToken)
+				if err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				var parts []string
+				if err := json.Unmarshal(decoded, &parts); err != nil {
+					t.Fatalf("unmarshal: %v", err)
+				}
+				parts[0] = "not-hex"
+				tokenJSON, err := json.Marshal(parts)
+				if err != nil {
+					t.Fatalf("marshal: %v", err)
+				}
+				return base64.StdEncoding.EncodeToString(tokenJSON)
+			},
+			wantErr: ErrSignatureMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := New(tt.didToken(t))
+			err := token.Validate()
+
+			if tt.wantValid {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("Validate() = nil, want error wrapping %v", tt.wantErr)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() = %v, want error wrapping %v", err, tt.wantErr)
+			}
+		})
+	}
+}
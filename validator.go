@@ -0,0 +1,54 @@
+package magic
+
+import "time"
+
+// Validator holds the options used to validate a Token, modeled after
+// jwt-go's Parser: a set of expected claim values plus knobs for leeway
+// and time so callers aren't at the mercy of time.Now() in tests.
+//
+// The zero value is a usable Validator that adds nothing to the checks
+// Validate makes: the default required fields, the signature, and the
+// expiry/not-before window with the library's default grace period.
+type Validator struct {
+	// ExpectedIssuer, when non-empty, must match the claim's "iss".
+	ExpectedIssuer string
+	// ExpectedAudience, when non-empty, must match the claim's "aud".
+	ExpectedAudience string
+	// ExpectedSubject, when non-empty, must match the claim's "sub".
+	ExpectedSubject string
+	// Leeway is the grace period applied around the "nbf" claim. It
+	// defaults to DIDTokenNBFGracePeriod seconds when nil; pass a pointer
+	// to a zero duration (e.g. a package-level `var noLeeway time.Duration`)
+	// to disable the grace period entirely.
+	Leeway *time.Duration
+	// Clock returns the current time and defaults to time.Now. Override
+	// it to validate a token against a fixed point in time.
+	Clock func() time.Time
+	// AdditionalRequiredFields are checked for presence in the claim on
+	// top of the library's own default required fields.
+	AdditionalRequiredFields []string
+	// ReplayStore, when set, is used to reject a DID token whose "tid"
+	// has already been seen. See ReplayStore for details.
+	ReplayStore ReplayStore
+}
+
+func (v *Validator) now() time.Time {
+	if v == nil || v.Clock == nil {
+		return time.Now()
+	}
+	return v.Clock()
+}
+
+func (v *Validator) leeway() time.Duration {
+	if v == nil || v.Leeway == nil {
+		return DIDTokenNBFGracePeriod * time.Second
+	}
+	return *v.Leeway
+}
+
+func (v *Validator) requiredFields() []string {
+	if v == nil || len(v.AdditionalRequiredFields) == 0 {
+		return defaultRequiredFields
+	}
+	return append(append([]string{}, defaultRequiredFields...), v.AdditionalRequiredFields...)
+}
@@ -0,0 +1,60 @@
+package magic
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTokenValidateWithExpectedClaims(t *testing.T) {
+	didToken := newSignedDIDToken(t, nil)
+	claim, err := New(didToken).Claim()
+	if err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		v       *Validator
+		wantErr error
+	}{
+		{
+			name: "matching issuer, audience and subject",
+			v: &Validator{
+				ExpectedIssuer:   claim.Iss,
+				ExpectedAudience: claim.Aud,
+				ExpectedSubject:  claim.Sub,
+			},
+		},
+		{
+			name:    "issuer mismatch",
+			v:       &Validator{ExpectedIssuer: "did:ethr:0xdeadbeef"},
+			wantErr: ErrIssuerMismatch,
+		},
+		{
+			name:    "audience mismatch",
+			v:       &Validator{ExpectedAudience: "wrong-audience"},
+			wantErr: ErrAudienceMismatch,
+		},
+		{
+			name:    "subject mismatch",
+			v:       &Validator{ExpectedSubject: "wrong-subject"},
+			wantErr: ErrSubjectMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(didToken).ValidateWith(context.Background(), tt.v)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("ValidateWith() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateWith() = %v, want error wrapping %v", err, tt.wantErr)
+			}
+		})
+	}
+}